@@ -0,0 +1,179 @@
+package teranode
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	as "github.com/aerospike/aerospike-client-go/v8"
+)
+
+// BatchOp describes a single UDF invocation to run as part of a Pipeline.
+// FunctionName and Args follow the same UDF calling convention as the
+// single-key methods on ClientWrapper (e.g. "spend", "unspend", "freeze").
+type BatchOp struct {
+	Key          *as.Key
+	FunctionName string
+	Args         []as.Value
+}
+
+// BatchResult carries the outcome of a single BatchOp, in the same position
+// it was submitted in, so partial failures never desynchronize callers from
+// their keys.
+type BatchResult struct {
+	Key   *as.Key
+	Value map[interface{}]interface{}
+	Err   error
+}
+
+// BatchConfig tunes the fan-out behaviour of Pipeline.
+type BatchConfig struct {
+	// MaxWorkers caps the number of concurrent BatchOperate round trips
+	// in flight at once. Zero means DefaultBatchConfig's value is used.
+	MaxWorkers int
+	// GroupSize is the number of ops sent to Aerospike per BatchOperate
+	// call. Zero means DefaultBatchConfig's value is used.
+	GroupSize int
+}
+
+// DefaultBatchConfig returns sensible defaults for cluster-sized subtree
+// processing.
+func DefaultBatchConfig() BatchConfig {
+	return BatchConfig{
+		MaxWorkers: 32,
+		GroupSize:  100,
+	}
+}
+
+// Pipeline coalesces many UDF calls into a bounded number of Aerospike batch
+// UDF round trips instead of one round trip per key. Ops are grouped into
+// chunks of cfg.GroupSize and dispatched across a worker pool capped at
+// cfg.MaxWorkers, so a subtree of thousands of TXs can be driven through
+// Spend/Unspend/Freeze/SetMined without saturating the client connection
+// pool. A failure in one op's UDF call is reported in that op's BatchResult
+// and never aborts the rest of the batch.
+func (w *ClientWrapper) Pipeline(ctx context.Context, policy *as.BatchPolicy, udfPolicy *as.BatchUDFPolicy, ops []BatchOp, cfg BatchConfig) ([]BatchResult, error) {
+	if len(ops) == 0 {
+		return nil, nil
+	}
+
+	def := DefaultBatchConfig()
+	if cfg.MaxWorkers <= 0 {
+		cfg.MaxWorkers = def.MaxWorkers
+	}
+	if cfg.GroupSize <= 0 {
+		cfg.GroupSize = def.GroupSize
+	}
+
+	results := make([]BatchResult, len(ops))
+	groups := groupBatchOps(ops, cfg.GroupSize)
+
+	sem := make(chan struct{}, cfg.MaxWorkers)
+	var wg sync.WaitGroup
+	var cancelled error
+	var mu sync.Mutex
+
+dispatch:
+	for _, g := range groups {
+		g := g
+		select {
+		case <-ctx.Done():
+			mu.Lock()
+			cancelled = ctx.Err()
+			mu.Unlock()
+			break dispatch
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			w.executeBatchGroup(policy, udfPolicy, g, results)
+		}()
+	}
+
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	return results, cancelled
+}
+
+// executeBatchGroup runs a single BatchOperate call for one group of ops and
+// writes each op's outcome back into its original slot in results.
+func (w *ClientWrapper) executeBatchGroup(policy *as.BatchPolicy, udfPolicy *as.BatchUDFPolicy, g batchGroup, results []BatchResult) {
+	records := make([]as.BatchRecordIfc, len(g.ops))
+	for i, op := range g.ops {
+		records[i] = as.NewBatchUDF(udfPolicy, op.Key, "teranode", op.FunctionName, op.Args...)
+	}
+
+	err := w.client.BatchOperate(policy, records)
+	group := buildBatchResults(g.ops, records, err)
+	for i, idx := range g.indexes {
+		results[idx] = group[i]
+	}
+}
+
+// buildBatchResults maps one group's ops and the BatchRecordIfc results
+// BatchOperate populated them with into per-op BatchResults, in the same
+// order as ops. If batchErr is non-nil, BatchOperate failed outright and
+// batchErr is attributed to every op rather than being dropped; otherwise
+// each op's outcome is read from its own record's ResultCode. It is kept
+// separate from executeBatchGroup so the ordering and partial-failure
+// behaviour can be tested without a live Aerospike connection.
+func buildBatchResults(ops []BatchOp, records []as.BatchRecordIfc, batchErr error) []BatchResult {
+	results := make([]BatchResult, len(ops))
+	if batchErr != nil {
+		for i, op := range ops {
+			results[i] = BatchResult{Key: op.Key, Err: batchErr}
+		}
+		return results
+	}
+
+	for i, op := range ops {
+		rec := records[i].BatchRec()
+		res := BatchResult{Key: op.Key}
+		if rec.ResultCode != 0 {
+			res.Err = fmt.Errorf("teranode: %s failed for key %v: result code %d", op.FunctionName, op.Key, rec.ResultCode)
+		} else if rec.Record != nil {
+			if v, ok := rec.Record.Bins["SUCCESS"]; ok {
+				if m, ok := v.(map[interface{}]interface{}); ok {
+					res.Value = m
+				}
+			}
+		}
+		results[i] = res
+	}
+	return results
+}
+
+// batchGroup is a contiguous slice of ops together with their original
+// positions in the caller's ops slice, so results can be written back in
+// order regardless of how groups complete.
+type batchGroup struct {
+	ops     []BatchOp
+	indexes []int
+}
+
+// groupBatchOps splits ops into fixed-size chunks by their position in the
+// input slice; it does not itself group by partition/node. That grouping is
+// handled for us one layer down: as.Client.BatchOperate splits each group's
+// BatchRecordIfc list by the keys' partitions and issues one sub-batch per
+// node internally, so chunking here only needs to bound how many keys ride
+// in a single BatchOperate call, not which node they land on.
+func groupBatchOps(ops []BatchOp, size int) []batchGroup {
+	groups := make([]batchGroup, 0, (len(ops)+size-1)/size)
+	for start := 0; start < len(ops); start += size {
+		end := start + size
+		if end > len(ops) {
+			end = len(ops)
+		}
+		indexes := make([]int, end-start)
+		for i := range indexes {
+			indexes[i] = start + i
+		}
+		groups = append(groups, batchGroup{ops: ops[start:end], indexes: indexes})
+	}
+	return groups
+}
@@ -0,0 +1,117 @@
+package teranode
+
+import (
+	"errors"
+	"testing"
+
+	as "github.com/aerospike/aerospike-client-go/v8"
+)
+
+func testBatchOps(n int) []BatchOp {
+	ops := make([]BatchOp, n)
+	for i := range ops {
+		key, _ := as.NewKey("test", "utxo", i)
+		ops[i] = BatchOp{Key: key, FunctionName: "spend"}
+	}
+	return ops
+}
+
+// TestGroupBatchOpsPreservesOrder pins that groupBatchOps chunks ops into
+// GroupSize-sized groups while keeping each op's original index alongside
+// it, so results can be written back to the right slot regardless of which
+// group completes first.
+func TestGroupBatchOpsPreservesOrder(t *testing.T) {
+	ops := testBatchOps(5)
+	groups := groupBatchOps(ops, 2)
+
+	if len(groups) != 3 {
+		t.Fatalf("got %d groups, want 3", len(groups))
+	}
+	wantSizes := []int{2, 2, 1}
+	for i, g := range groups {
+		if len(g.ops) != wantSizes[i] {
+			t.Fatalf("group %d has %d ops, want %d", i, len(g.ops), wantSizes[i])
+		}
+	}
+
+	// Every original index must appear exactly once across all groups, in
+	// the same relative order as ops.
+	var gotIndexes []int
+	for _, g := range groups {
+		gotIndexes = append(gotIndexes, g.indexes...)
+	}
+	if len(gotIndexes) != len(ops) {
+		t.Fatalf("got %d indexes total, want %d", len(gotIndexes), len(ops))
+	}
+	for i, idx := range gotIndexes {
+		if idx != i {
+			t.Fatalf("indexes[%d] = %d, want %d", i, idx, i)
+		}
+	}
+}
+
+// TestBuildBatchResultsPartialFailure pins Pipeline's result-ordering
+// contract under partial failure: when some ops in a group fail and others
+// succeed, each BatchResult must land in the same position as its BatchOp,
+// carrying that op's own error (or success value) rather than another op's.
+func TestBuildBatchResultsPartialFailure(t *testing.T) {
+	ops := testBatchOps(3)
+
+	records := make([]as.BatchRecordIfc, len(ops))
+	records[0] = as.NewBatchUDF(nil, ops[0].Key, "teranode", "spend")
+	records[1] = as.NewBatchUDF(nil, ops[1].Key, "teranode", "spend")
+	records[2] = as.NewBatchUDF(nil, ops[2].Key, "teranode", "spend")
+
+	// Simulate op 1 failing (e.g. already spent) while 0 and 2 succeed.
+	// NewBatchUDF starts every record at NO_RESPONSE; BatchOperate sets OK
+	// (0) on the ones that succeed.
+	records[0].BatchRec().ResultCode = 0
+	records[1].BatchRec().ResultCode = 1 // any non-zero result code
+	records[2].BatchRec().ResultCode = 0
+
+	results := buildBatchResults(ops, records, nil)
+
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	if results[0].Err != nil {
+		t.Fatalf("results[0].Err = %v, want nil", results[0].Err)
+	}
+	if results[0].Key != ops[0].Key {
+		t.Fatalf("results[0].Key does not match ops[0].Key")
+	}
+	if results[1].Err == nil {
+		t.Fatalf("results[1].Err = nil, want an error for the failed op")
+	}
+	if results[1].Key != ops[1].Key {
+		t.Fatalf("results[1].Key does not match ops[1].Key")
+	}
+	if results[2].Err != nil {
+		t.Fatalf("results[2].Err = %v, want nil", results[2].Err)
+	}
+	if results[2].Key != ops[2].Key {
+		t.Fatalf("results[2].Key does not match ops[2].Key")
+	}
+}
+
+// TestBuildBatchResultsOutrightFailure pins that a BatchOperate call that
+// fails outright attributes the same error to every op in the group, rather
+// than dropping their results.
+func TestBuildBatchResultsOutrightFailure(t *testing.T) {
+	ops := testBatchOps(2)
+	batchErr := errors.New("connection reset")
+
+	results := buildBatchResults(ops, nil, batchErr)
+
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	for i, res := range results {
+		if !errors.Is(res.Err, batchErr) {
+			t.Fatalf("results[%d].Err = %v, want %v", i, res.Err, batchErr)
+		}
+		if res.Key != ops[i].Key {
+			t.Fatalf("results[%d].Key does not match ops[%d].Key", i, i)
+		}
+	}
+}
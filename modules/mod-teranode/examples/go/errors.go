@@ -0,0 +1,47 @@
+package teranode
+
+import "errors"
+
+// Typed errors returned by ClientWrapper methods, mapped from the "status"
+// field of a UDF's raw response. Callers can use errors.Is against these
+// sentinels instead of string-matching a status code.
+var (
+	ErrAlreadySpent      = errors.New("teranode: utxo already spent")
+	ErrFrozen            = errors.New("teranode: utxo is frozen")
+	ErrLocked            = errors.New("teranode: transaction is locked")
+	ErrConflicting       = errors.New("teranode: transaction is conflicting")
+	ErrHashMismatch      = errors.New("teranode: utxo hash mismatch")
+	ErrRetentionExceeded = errors.New("teranode: blockHeightRetention exceeds policy limit")
+)
+
+// statusErrors maps a UDF's "status" response field to a typed error.
+var statusErrors = map[string]error{
+	"ERROR_ALREADY_SPENT":      ErrAlreadySpent,
+	"ERROR_FROZEN":             ErrFrozen,
+	"ERROR_LOCKED":             ErrLocked,
+	"ERROR_CONFLICTING":        ErrConflicting,
+	"ERROR_HASH_MISMATCH":      ErrHashMismatch,
+	"ERROR_RETENTION_EXCEEDED": ErrRetentionExceeded,
+}
+
+// StatusError wraps a UDF status code that doesn't map to one of the
+// package's typed sentinel errors above.
+type StatusError struct {
+	Status string
+}
+
+func (e *StatusError) Error() string {
+	return "teranode: udf returned status " + e.Status
+}
+
+// errorForStatus resolves a UDF status code to a typed error. A status of
+// "" or "OK" means success and resolves to nil.
+func errorForStatus(status string) error {
+	if status == "" || status == "OK" {
+		return nil
+	}
+	if err, ok := statusErrors[status]; ok {
+		return err
+	}
+	return &StatusError{Status: status}
+}
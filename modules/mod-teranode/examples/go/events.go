@@ -0,0 +1,183 @@
+package teranode
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	as "github.com/aerospike/aerospike-client-go/v8"
+)
+
+// EventType identifies the kind of UTXO state transition an Event records.
+type EventType string
+
+// Event types emitted by the UDFs on every record mutation. Each maps
+// directly to a ClientWrapper method: Spend emits Spent, Unspend emits
+// Unspent, and so on.
+const (
+	EventSpent       EventType = "SPENT"
+	EventUnspent     EventType = "UNSPENT"
+	EventFrozen      EventType = "FROZEN"
+	EventUnfrozen    EventType = "UNFROZEN"
+	EventReassigned  EventType = "REASSIGNED"
+	EventMined       EventType = "MINED"
+	EventConflicting EventType = "CONFLICTING"
+	EventLocked      EventType = "LOCKED"
+	EventPreserved   EventType = "PRESERVED"
+)
+
+// eventSet is the dedicated set each UDF appends its compact log entry to.
+const eventSet = "teranode_events"
+
+// Cursor resumes a Subscribe stream from a specific point, so a reconnecting
+// subscriber never misses or replays events.
+type Cursor struct {
+	// Seq is the last sequence number the subscriber has already
+	// delivered; Subscribe resumes strictly after it.
+	Seq int64
+	// BlockHeight is the block height of the last delivered event. Subscribe
+	// additionally filters out any record below it, so a stale Seq left
+	// over from before the event log was pruned and restarted can't replay
+	// events the subscriber has already seen.
+	BlockHeight int64
+}
+
+// Event is a single UTXO state transition recorded by a UDF.
+type Event struct {
+	Seq         int64
+	Type        EventType
+	Key         *as.Key
+	BlockHeight int64
+	Data        map[interface{}]interface{}
+}
+
+// EventFilter narrows a Subscribe stream to a subset of events.
+type EventFilter struct {
+	// Types, if non-empty, restricts delivery to these event types.
+	Types []EventType
+	// KeyPrefix, if non-empty, restricts delivery to events whose key
+	// digest starts with this prefix.
+	KeyPrefix []byte
+}
+
+func (f EventFilter) matches(e Event) bool {
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if t == e.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.KeyPrefix) > 0 {
+		digest := e.Key.Digest()
+		if len(digest) < len(f.KeyPrefix) {
+			return false
+		}
+		for i, b := range f.KeyPrefix {
+			if digest[i] != b {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Subscribe tails the namespace's event set starting strictly after cursor,
+// delivering events matching filter on the returned channel. It polls using
+// Aerospike's query API for records with seq > cursor.Seq, rather than
+// holding a persistent server-side cursor, so a subscriber can reconnect at
+// any later time with the same Cursor and pick up exactly where it left
+// off. The channel is closed when ctx is cancelled.
+func (w *ClientWrapper) Subscribe(ctx context.Context, namespace string, cursor Cursor, filter EventFilter, pollInterval time.Duration) (<-chan Event, error) {
+	out := make(chan Event, 64)
+
+	go func() {
+		defer close(out)
+		last := cursor
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			events, err := w.pollEvents(namespace, last)
+			if err == nil {
+				for _, e := range events {
+					if !filter.matches(e) {
+						continue
+					}
+					select {
+					case out <- e:
+					case <-ctx.Done():
+						return
+					}
+					last = Cursor{Seq: e.Seq, BlockHeight: e.BlockHeight}
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// pollEvents queries the event set for every record with seq > after.Seq,
+// returning them in ascending sequence order. after.BlockHeight additionally
+// bounds the scan: any record with blockHeight < after.BlockHeight is
+// dropped even if its seq qualifies, guarding against a resumed cursor
+// replaying events from before the height the caller already persisted (for
+// example after the event log itself was pruned and recreated with a fresh
+// sequence).
+func (w *ClientWrapper) pollEvents(namespace string, after Cursor) ([]Event, error) {
+	stmt := as.NewStatement(namespace, eventSet)
+	if err := stmt.SetFilter(as.NewRangeFilter("seq", after.Seq+1, int64(^uint64(0)>>1))); err != nil {
+		return nil, err
+	}
+
+	recordset, err := w.client.Query(nil, stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer recordset.Close()
+
+	var events []Event
+	for res := range recordset.Results() {
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		e := recordToEvent(res.Record)
+		if e.BlockHeight < after.BlockHeight {
+			continue
+		}
+		events = append(events, e)
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].Seq < events[j].Seq })
+	return events, nil
+}
+
+// recordToEvent decodes a raw event-set record into an Event.
+func recordToEvent(rec *as.Record) Event {
+	e := Event{Key: rec.Key, Data: make(map[interface{}]interface{})}
+	e.Seq = toInt64(rec.Bins["seq"])
+	if typ, ok := rec.Bins["type"].(string); ok {
+		e.Type = EventType(typ)
+	}
+	e.BlockHeight = toInt64(rec.Bins["blockHeight"])
+	for k, v := range rec.Bins {
+		if k == "seq" || k == "type" || k == "blockHeight" {
+			continue
+		}
+		e.Data[k] = v
+	}
+	return e
+}
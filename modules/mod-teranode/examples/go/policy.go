@@ -0,0 +1,216 @@
+package teranode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	as "github.com/aerospike/aerospike-client-go/v8"
+	atypes "github.com/aerospike/aerospike-client-go/v8/types"
+)
+
+// maxSetPolicyAttempts bounds the generation-check retry loop in SetPolicy
+// so a hot contended namespace fails loudly instead of retrying forever.
+const maxSetPolicyAttempts = 10
+
+// policyBinVersion, policyBinData name the bins of the single record each
+// namespace uses to hold its current Policy.
+const (
+	policyBinVersion = "version"
+	policyBinData    = "policy"
+)
+
+// Policy is the set of cluster-wide, hot-reloadable limits enforced inside
+// the UDFs on every invocation. Operators tune these (e.g. capping
+// SpendMulti at 1000 offsets) without redeploying the Lua module.
+type Policy struct {
+	// Version is bumped on every SetPolicy call; UDFs and Watch both key
+	// off it to detect changes.
+	Version int64
+	// MaxUTXOsPerRecord caps the number of outputs a single record may hold.
+	MaxUTXOsPerRecord int64
+	// MaxSpendsPerSpendMulti caps the number of offsets a single
+	// SpendMulti call may spend at once.
+	MaxSpendsPerSpendMulti int64
+	// MinBlockHeightRetention and MaxBlockHeightRetention bound the
+	// blockHeightRetention argument accepted by Spend, Unspend, SetMined,
+	// and related calls.
+	MinBlockHeightRetention int64
+	MaxBlockHeightRetention int64
+	// FeePerSpend is charged per spend offset when the UDF's caller
+	// identity carries a billing account.
+	FeePerSpend int64
+	// RateQuotaPerCaller caps the number of UDF calls per second a single
+	// caller identity may issue before being rejected.
+	RateQuotaPerCaller int64
+}
+
+// policyKey is the fixed key every namespace's Policy record lives at.
+func policyKey(namespace string) (*as.Key, error) {
+	return as.NewKey(namespace, "teranode", "policy")
+}
+
+// errGenerationConflict marks a casRetry attempt that lost a concurrent
+// generation-check race and should be retried against the new generation.
+var errGenerationConflict = fmt.Errorf("teranode: generation conflict")
+
+// casRetry runs attempt up to maxAttempts times, retrying only when it
+// returns errGenerationConflict and returning any other error immediately.
+// It backs SetPolicy's generation-check loop; pulling it out lets the
+// retry-on-conflict/give-up-after-N/bail-on-other-errors behaviour be tested
+// without a live Aerospike connection.
+func casRetry(maxAttempts int, attempt func() error) error {
+	for i := 0; i < maxAttempts; i++ {
+		err := attempt()
+		if err == nil {
+			return nil
+		}
+		if err != errGenerationConflict {
+			return err
+		}
+	}
+	return fmt.Errorf("teranode: gave up after %d attempts due to concurrent updates", maxAttempts)
+}
+
+// SetPolicy writes a new cluster-wide Policy, incrementing its Version so
+// that UDFs and any active Watch callers pick up the change. Version in the
+// supplied policy is ignored and overwritten. The read-increment-write is
+// guarded by the record's generation: if another SetPolicy call wins the
+// race, this one re-reads the new current Version and retries, rather than
+// silently clobbering the other update.
+func (w *ClientWrapper) SetPolicy(policy *as.WritePolicy, namespace string, p Policy) (Policy, error) {
+	key, err := policyKey(namespace)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	var result Policy
+	err = casRetry(maxSetPolicyAttempts, func() error {
+		current, generation, err := w.getPolicyRecord(key)
+		if err != nil {
+			return err
+		}
+		p.Version = current.Version + 1
+
+		bins := as.BinMap{
+			policyBinVersion:          p.Version,
+			"maxUtxosPerRecord":       p.MaxUTXOsPerRecord,
+			"maxSpendsPerSpendMulti":  p.MaxSpendsPerSpendMulti,
+			"minBlockHeightRetention": p.MinBlockHeightRetention,
+			"maxBlockHeightRetention": p.MaxBlockHeightRetention,
+			"feePerSpend":             p.FeePerSpend,
+			"rateQuotaPerCaller":      p.RateQuotaPerCaller,
+		}
+
+		wp := as.NewWritePolicy(generation, 0)
+		if policy != nil {
+			*wp = *policy
+			wp.Generation = generation
+		}
+		wp.GenerationPolicy = as.EXPECT_GEN_EQUAL
+
+		putErr := w.client.Put(wp, key, bins)
+		if putErr == nil {
+			result = p
+			return nil
+		}
+		if ae, ok := putErr.(*as.AerospikeError); ok && ae.ResultCode == atypes.GENERATION_ERROR {
+			return errGenerationConflict // another SetPolicy won the race; retry against the new generation
+		}
+		return putErr
+	})
+	if err != nil {
+		return Policy{}, err
+	}
+	return result, nil
+}
+
+// GetPolicy reads the current Policy for namespace. If no policy has been
+// set yet, it returns the zero Policy (Version 0) rather than an error, so
+// UDFs and callers have a well-defined "no limits configured" state.
+func (w *ClientWrapper) GetPolicy(namespace string) (Policy, error) {
+	key, err := policyKey(namespace)
+	if err != nil {
+		return Policy{}, err
+	}
+	p, _, err := w.getPolicyRecord(key)
+	return p, err
+}
+
+// getPolicyRecord reads the Policy record at key along with its Aerospike
+// generation, so SetPolicy can CAS its update against a concurrent writer.
+func (w *ClientWrapper) getPolicyRecord(key *as.Key) (Policy, uint32, error) {
+	rec, err := w.client.Get(nil, key)
+	if err != nil {
+		if errors.Is(err, as.ErrKeyNotFound) {
+			return Policy{}, 0, nil
+		}
+		return Policy{}, 0, err
+	}
+
+	p := Policy{
+		Version:                 toInt64(rec.Bins[policyBinVersion]),
+		MaxUTXOsPerRecord:       toInt64(rec.Bins["maxUtxosPerRecord"]),
+		MaxSpendsPerSpendMulti:  toInt64(rec.Bins["maxSpendsPerSpendMulti"]),
+		MinBlockHeightRetention: toInt64(rec.Bins["minBlockHeightRetention"]),
+		MaxBlockHeightRetention: toInt64(rec.Bins["maxBlockHeightRetention"]),
+		FeePerSpend:             toInt64(rec.Bins["feePerSpend"]),
+		RateQuotaPerCaller:      toInt64(rec.Bins["rateQuotaPerCaller"]),
+	}
+	return p, rec.Generation, nil
+}
+
+// WatchPolicy polls GetPolicy every interval and delivers the new Policy on
+// the returned channel whenever its Version changes, so long-running Go
+// clients pick up operator changes without restarting. The channel is
+// closed when ctx is cancelled.
+func (w *ClientWrapper) WatchPolicy(ctx context.Context, namespace string, interval time.Duration) (<-chan Policy, error) {
+	initial, err := w.GetPolicy(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Policy, 1)
+	go func() {
+		defer close(ch)
+		lastVersion := initial.Version
+		ch <- initial
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p, err := w.GetPolicy(namespace)
+				if err != nil {
+					continue
+				}
+				if p.Version != lastVersion {
+					lastVersion = p.Version
+					select {
+					case ch <- p:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,61 @@
+package teranode
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestCASRetryRetriesOnGenerationConflict pins SetPolicy's core guarantee:
+// a generation conflict on the write is retried against a fresh read rather
+// than surfaced to the caller, so a losing racer doesn't have to retry
+// itself.
+func TestCASRetryRetriesOnGenerationConflict(t *testing.T) {
+	attempts := 0
+	err := casRetry(maxSetPolicyAttempts, func() error {
+		attempts++
+		if attempts < 3 {
+			return errGenerationConflict
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want 3 (2 conflicts then a success)", attempts)
+	}
+}
+
+// TestCASRetryGivesUpAfterMaxAttempts pins that a namespace stuck losing
+// every generation race fails loudly after maxAttempts rather than retrying
+// forever.
+func TestCASRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	attempts := 0
+	err := casRetry(3, func() error {
+		attempts++
+		return errGenerationConflict
+	})
+	if err == nil {
+		t.Fatalf("expected an error after exhausting retries, got nil")
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want 3", attempts)
+	}
+}
+
+// TestCASRetryPropagatesOtherErrors pins that a non-conflict error (e.g. a
+// network failure) is returned immediately, without retrying.
+func TestCASRetryPropagatesOtherErrors(t *testing.T) {
+	wantErr := errors.New("connection reset")
+	attempts := 0
+	err := casRetry(maxSetPolicyAttempts, func() error {
+		attempts++
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("err = %v, want %v", err, wantErr)
+	}
+	if attempts != 1 {
+		t.Fatalf("attempts = %d, want 1 (no retry on a non-conflict error)", attempts)
+	}
+}
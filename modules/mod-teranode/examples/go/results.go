@@ -0,0 +1,320 @@
+package teranode
+
+import "fmt"
+
+// resultBase holds the fields common to every typed UDF result: the raw
+// status code, and the raw response map for fields not yet promoted to a
+// typed accessor on the embedding struct.
+type resultBase struct {
+	Status string
+	Raw    map[interface{}]interface{}
+}
+
+// Success reports whether the UDF call completed without an error status.
+func (r resultBase) Success() bool {
+	return r.Status == "" || r.Status == "OK"
+}
+
+// SpendResult is the typed result of a Spend call.
+type SpendResult struct {
+	resultBase
+	Offset int64
+	// SpendingData is the spendingData now recorded against the UTXO: the
+	// caller's own spendingData on success, or the conflicting spend's
+	// spendingData when the call returns ErrConflicting or ErrAlreadySpent.
+	SpendingData []byte
+}
+
+func newSpendResult(raw map[interface{}]interface{}) (SpendResult, error) {
+	base, err := unmarshal(raw)
+	r := SpendResult{resultBase: base}
+	if raw != nil {
+		r.Offset = toInt64(raw["offset"])
+		r.SpendingData = toBytes(raw["spendingData"])
+	}
+	return r, err
+}
+
+// SpendMultiResult is the typed result of a SpendMulti call.
+type SpendMultiResult struct {
+	resultBase
+	// SpentOffsets are the offsets that were successfully spent.
+	SpentOffsets []int64
+	// FailedOffsets are the offsets rejected by the call (e.g. already
+	// spent, frozen, or locked), so a partial SpendMulti failure doesn't
+	// require the caller to diff the input against SpentOffsets itself.
+	FailedOffsets []int64
+}
+
+func newSpendMultiResult(raw map[interface{}]interface{}) (SpendMultiResult, error) {
+	base, err := unmarshal(raw)
+	r := SpendMultiResult{resultBase: base}
+	if raw != nil {
+		r.SpentOffsets = toInt64Slice(raw["spentOffsets"])
+		r.FailedOffsets = toInt64Slice(raw["failedOffsets"])
+	}
+	return r, err
+}
+
+// UnspendResult is the typed result of an Unspend call.
+type UnspendResult struct {
+	resultBase
+	Offset int64
+}
+
+func newUnspendResult(raw map[interface{}]interface{}) (UnspendResult, error) {
+	base, err := unmarshal(raw)
+	r := UnspendResult{resultBase: base}
+	if raw != nil {
+		r.Offset = toInt64(raw["offset"])
+	}
+	return r, err
+}
+
+// FreezeResult is the typed result of a Freeze call.
+type FreezeResult struct {
+	resultBase
+	Offset int64
+}
+
+func newFreezeResult(raw map[interface{}]interface{}) (FreezeResult, error) {
+	base, err := unmarshal(raw)
+	r := FreezeResult{resultBase: base}
+	if raw != nil {
+		r.Offset = toInt64(raw["offset"])
+	}
+	return r, err
+}
+
+// UnfreezeResult is the typed result of an Unfreeze call.
+type UnfreezeResult struct {
+	resultBase
+	Offset int64
+}
+
+func newUnfreezeResult(raw map[interface{}]interface{}) (UnfreezeResult, error) {
+	base, err := unmarshal(raw)
+	r := UnfreezeResult{resultBase: base}
+	if raw != nil {
+		r.Offset = toInt64(raw["offset"])
+	}
+	return r, err
+}
+
+// ReassignResult is the typed result of a Reassign call.
+type ReassignResult struct {
+	resultBase
+	Offset      int64
+	NewUtxoHash []byte
+}
+
+func newReassignResult(raw map[interface{}]interface{}) (ReassignResult, error) {
+	base, err := unmarshal(raw)
+	r := ReassignResult{resultBase: base}
+	if raw != nil {
+		r.Offset = toInt64(raw["offset"])
+		r.NewUtxoHash = toBytes(raw["newUtxoHash"])
+	}
+	return r, err
+}
+
+// SetMinedResult is the typed result of a SetMined call.
+type SetMinedResult struct {
+	resultBase
+	BlockID     []byte
+	BlockHeight int64
+	SubtreeIdx  int64
+}
+
+func newSetMinedResult(raw map[interface{}]interface{}) (SetMinedResult, error) {
+	base, err := unmarshal(raw)
+	r := SetMinedResult{resultBase: base}
+	if raw != nil {
+		r.BlockID = toBytes(raw["blockID"])
+		r.BlockHeight = toInt64(raw["blockHeight"])
+		r.SubtreeIdx = toInt64(raw["subtreeIdx"])
+	}
+	return r, err
+}
+
+// SetConflictingResult is the typed result of a SetConflicting call.
+type SetConflictingResult struct {
+	resultBase
+	Conflicting bool
+}
+
+func newSetConflictingResult(raw map[interface{}]interface{}) (SetConflictingResult, error) {
+	base, err := unmarshal(raw)
+	r := SetConflictingResult{resultBase: base}
+	if raw != nil {
+		r.Conflicting = toBool(raw["conflicting"])
+	}
+	return r, err
+}
+
+// SetLockedResult is the typed result of a SetLocked call.
+type SetLockedResult struct {
+	resultBase
+	Locked bool
+}
+
+func newSetLockedResult(raw map[interface{}]interface{}) (SetLockedResult, error) {
+	base, err := unmarshal(raw)
+	r := SetLockedResult{resultBase: base}
+	if raw != nil {
+		r.Locked = toBool(raw["locked"])
+	}
+	return r, err
+}
+
+// PreserveUntilResult is the typed result of a PreserveUntil call.
+type PreserveUntilResult struct {
+	resultBase
+	PreserveUntilHeight int64
+}
+
+func newPreserveUntilResult(raw map[interface{}]interface{}) (PreserveUntilResult, error) {
+	base, err := unmarshal(raw)
+	r := PreserveUntilResult{resultBase: base}
+	if raw != nil {
+		r.PreserveUntilHeight = toInt64(raw["preserveUntilHeight"])
+	}
+	return r, err
+}
+
+// IncrementSpentExtraRecsResult is the typed result of an
+// IncrementSpentExtraRecs call.
+type IncrementSpentExtraRecsResult struct {
+	resultBase
+	SpentExtraRecs int64
+}
+
+func newIncrementSpentExtraRecsResult(raw map[interface{}]interface{}) (IncrementSpentExtraRecsResult, error) {
+	base, err := unmarshal(raw)
+	r := IncrementSpentExtraRecsResult{resultBase: base}
+	if raw != nil {
+		r.SpentExtraRecs = toInt64(raw["spentExtraRecs"])
+	}
+	return r, err
+}
+
+// SetDeleteAtHeightResult is the typed result of a SetDeleteAtHeight call.
+type SetDeleteAtHeightResult struct {
+	resultBase
+	DeleteAtHeight int64
+}
+
+func newSetDeleteAtHeightResult(raw map[interface{}]interface{}) (SetDeleteAtHeightResult, error) {
+	base, err := unmarshal(raw)
+	r := SetDeleteAtHeightResult{resultBase: base}
+	if raw != nil {
+		r.DeleteAtHeight = toInt64(raw["deleteAtHeight"])
+	}
+	return r, err
+}
+
+// unmarshal decodes a UDF's raw map[interface{}]interface{} response into a
+// resultBase, and returns the typed error (if any) that corresponds to its
+// status code. Every per-operation new*Result function calls this first for
+// the fields common to all results, then layers its own typed fields on top.
+func unmarshal(raw map[interface{}]interface{}) (resultBase, error) {
+	base := resultBase{Raw: raw}
+	if raw == nil {
+		return base, nil
+	}
+	if v, ok := raw["status"]; ok {
+		if s, ok := v.(string); ok {
+			base.Status = s
+		}
+	}
+	return base, errorForStatus(base.Status)
+}
+
+// toBytes extracts a []byte bin value, defaulting to nil if absent or of a
+// different type.
+func toBytes(v interface{}) []byte {
+	b, _ := v.([]byte)
+	return b
+}
+
+// toBool extracts a bool bin value, defaulting to false if absent or of a
+// different type.
+func toBool(v interface{}) bool {
+	b, _ := v.(bool)
+	return b
+}
+
+// toInt64Slice extracts a []int64 from a bin value holding a
+// []interface{} list of integers, as the Aerospike client decodes Lua
+// number lists.
+func toInt64Slice(v interface{}) []int64 {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]int64, 0, len(list))
+	for _, item := range list {
+		out = append(out, toInt64(item))
+	}
+	return out
+}
+
+// Unmarshal decodes a raw UDF response map into one of the package's typed
+// Result structs, for callers migrating off the old
+// map[interface{}]interface{} return format. dst must be a pointer to one of
+// the per-operation Result types defined in this file;
+// SpendAcrossRecordsResult is not supported.
+func Unmarshal(raw map[interface{}]interface{}, dst interface{}) error {
+	switch d := dst.(type) {
+	case *SpendResult:
+		r, err := newSpendResult(raw)
+		*d = r
+		return err
+	case *SpendMultiResult:
+		r, err := newSpendMultiResult(raw)
+		*d = r
+		return err
+	case *UnspendResult:
+		r, err := newUnspendResult(raw)
+		*d = r
+		return err
+	case *FreezeResult:
+		r, err := newFreezeResult(raw)
+		*d = r
+		return err
+	case *UnfreezeResult:
+		r, err := newUnfreezeResult(raw)
+		*d = r
+		return err
+	case *ReassignResult:
+		r, err := newReassignResult(raw)
+		*d = r
+		return err
+	case *SetMinedResult:
+		r, err := newSetMinedResult(raw)
+		*d = r
+		return err
+	case *SetConflictingResult:
+		r, err := newSetConflictingResult(raw)
+		*d = r
+		return err
+	case *SetLockedResult:
+		r, err := newSetLockedResult(raw)
+		*d = r
+		return err
+	case *PreserveUntilResult:
+		r, err := newPreserveUntilResult(raw)
+		*d = r
+		return err
+	case *IncrementSpentExtraRecsResult:
+		r, err := newIncrementSpentExtraRecsResult(raw)
+		*d = r
+		return err
+	case *SetDeleteAtHeightResult:
+		r, err := newSetDeleteAtHeightResult(raw)
+		*d = r
+		return err
+	default:
+		return fmt.Errorf("teranode: Unmarshal: unsupported type %T", dst)
+	}
+}
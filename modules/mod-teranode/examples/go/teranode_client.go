@@ -27,8 +27,8 @@ func (w *ClientWrapper) execute(policy *as.WritePolicy, key *as.Key, functionNam
 }
 
 // Spend marks a single UTXO as spent
-func (w *ClientWrapper) Spend(policy *as.WritePolicy, key *as.Key, offset int64, utxoHash []byte, spendingData []byte, ignoreConflicting bool, ignoreLocked bool, currentBlockHeight int64, blockHeightRetention int64) (map[interface{}]interface{}, error) {
-	return w.execute(policy, key, "spend",
+func (w *ClientWrapper) Spend(policy *as.WritePolicy, key *as.Key, offset int64, utxoHash []byte, spendingData []byte, ignoreConflicting bool, ignoreLocked bool, currentBlockHeight int64, blockHeightRetention int64) (SpendResult, error) {
+	raw, err := w.execute(policy, key, "spend",
 		as.NewLongValue(offset),
 		as.NewBytesValue(utxoHash),
 		as.NewBytesValue(spendingData),
@@ -37,65 +37,89 @@ func (w *ClientWrapper) Spend(policy *as.WritePolicy, key *as.Key, offset int64,
 		as.NewLongValue(currentBlockHeight),
 		as.NewLongValue(blockHeightRetention),
 	)
+	if err != nil {
+		return SpendResult{}, err
+	}
+	return newSpendResult(raw)
 }
 
 // SpendMulti marks multiple UTXOs as spent in one operation
-func (w *ClientWrapper) SpendMulti(policy *as.WritePolicy, key *as.Key, spends []map[string]interface{}, ignoreConflicting bool, ignoreLocked bool, currentBlockHeight int64, blockHeightRetention int64) (map[interface{}]interface{}, error) {
+func (w *ClientWrapper) SpendMulti(policy *as.WritePolicy, key *as.Key, spends []map[string]interface{}, ignoreConflicting bool, ignoreLocked bool, currentBlockHeight int64, blockHeightRetention int64) (SpendMultiResult, error) {
 	// Convert Go map to generic interface map for Aerospike
 	spendList := make([]interface{}, len(spends))
 	for i, s := range spends {
 		spendList[i] = s
 	}
 
-	return w.execute(policy, key, "spendMulti",
+	raw, err := w.execute(policy, key, "spendMulti",
 		as.NewListValue(spendList),
 		as.NewValue(ignoreConflicting),
 		as.NewValue(ignoreLocked),
 		as.NewLongValue(currentBlockHeight),
 		as.NewLongValue(blockHeightRetention),
 	)
+	if err != nil {
+		return SpendMultiResult{}, err
+	}
+	return newSpendMultiResult(raw)
 }
 
 // Unspend reverses a spend operation
-func (w *ClientWrapper) Unspend(policy *as.WritePolicy, key *as.Key, offset int64, utxoHash []byte, currentBlockHeight int64, blockHeightRetention int64) (map[interface{}]interface{}, error) {
-	return w.execute(policy, key, "unspend",
+func (w *ClientWrapper) Unspend(policy *as.WritePolicy, key *as.Key, offset int64, utxoHash []byte, currentBlockHeight int64, blockHeightRetention int64) (UnspendResult, error) {
+	raw, err := w.execute(policy, key, "unspend",
 		as.NewLongValue(offset),
 		as.NewBytesValue(utxoHash),
 		as.NewLongValue(currentBlockHeight),
 		as.NewLongValue(blockHeightRetention),
 	)
+	if err != nil {
+		return UnspendResult{}, err
+	}
+	return newUnspendResult(raw)
 }
 
 // Freeze prevents a UTXO from being spent
-func (w *ClientWrapper) Freeze(policy *as.WritePolicy, key *as.Key, offset int64, utxoHash []byte) (map[interface{}]interface{}, error) {
-	return w.execute(policy, key, "freeze",
+func (w *ClientWrapper) Freeze(policy *as.WritePolicy, key *as.Key, offset int64, utxoHash []byte) (FreezeResult, error) {
+	raw, err := w.execute(policy, key, "freeze",
 		as.NewLongValue(offset),
 		as.NewBytesValue(utxoHash),
 	)
+	if err != nil {
+		return FreezeResult{}, err
+	}
+	return newFreezeResult(raw)
 }
 
 // Unfreeze allows a previously frozen UTXO to be spent
-func (w *ClientWrapper) Unfreeze(policy *as.WritePolicy, key *as.Key, offset int64, utxoHash []byte) (map[interface{}]interface{}, error) {
-	return w.execute(policy, key, "unfreeze",
+func (w *ClientWrapper) Unfreeze(policy *as.WritePolicy, key *as.Key, offset int64, utxoHash []byte) (UnfreezeResult, error) {
+	raw, err := w.execute(policy, key, "unfreeze",
 		as.NewLongValue(offset),
 		as.NewBytesValue(utxoHash),
 	)
+	if err != nil {
+		return UnfreezeResult{}, err
+	}
+	return newUnfreezeResult(raw)
 }
 
 // Reassign changes a UTXO hash (used for frozen UTXOs)
-func (w *ClientWrapper) Reassign(policy *as.WritePolicy, key *as.Key, offset int64, utxoHash []byte, newUtxoHash []byte, blockHeight int64, spendableAfter int64) (map[interface{}]interface{}, error) {
-	return w.execute(policy, key, "reassign",
+func (w *ClientWrapper) Reassign(policy *as.WritePolicy, key *as.Key, offset int64, utxoHash []byte, newUtxoHash []byte, blockHeight int64, spendableAfter int64) (ReassignResult, error) {
+	raw, err := w.execute(policy, key, "reassign",
 		as.NewLongValue(offset),
 		as.NewBytesValue(utxoHash),
 		as.NewBytesValue(newUtxoHash),
 		as.NewLongValue(blockHeight),
 		as.NewLongValue(spendableAfter),
 	)
+	if err != nil {
+		return ReassignResult{}, err
+	}
+	return newReassignResult(raw)
 }
 
 // SetMined tracks block height and ID for a transaction
-func (w *ClientWrapper) SetMined(policy *as.WritePolicy, key *as.Key, blockID []byte, blockHeight int64, subtreeIdx int64, currentBlockHeight int64, blockHeightRetention int64, onLongestChain bool, unsetMined bool) (map[interface{}]interface{}, error) {
-	return w.execute(policy, key, "setMined",
+func (w *ClientWrapper) SetMined(policy *as.WritePolicy, key *as.Key, blockID []byte, blockHeight int64, subtreeIdx int64, currentBlockHeight int64, blockHeightRetention int64, onLongestChain bool, unsetMined bool) (SetMinedResult, error) {
+	raw, err := w.execute(policy, key, "setMined",
 		as.NewBytesValue(blockID),
 		as.NewLongValue(blockHeight),
 		as.NewLongValue(subtreeIdx),
@@ -104,44 +128,68 @@ func (w *ClientWrapper) SetMined(policy *as.WritePolicy, key *as.Key, blockID []
 		as.NewValue(onLongestChain),
 		as.NewValue(unsetMined),
 	)
+	if err != nil {
+		return SetMinedResult{}, err
+	}
+	return newSetMinedResult(raw)
 }
 
 // SetConflicting marks/unmarks a transaction as conflicting
-func (w *ClientWrapper) SetConflicting(policy *as.WritePolicy, key *as.Key, setValue bool, currentBlockHeight int64, blockHeightRetention int64) (map[interface{}]interface{}, error) {
-	return w.execute(policy, key, "setConflicting",
+func (w *ClientWrapper) SetConflicting(policy *as.WritePolicy, key *as.Key, setValue bool, currentBlockHeight int64, blockHeightRetention int64) (SetConflictingResult, error) {
+	raw, err := w.execute(policy, key, "setConflicting",
 		as.NewValue(setValue),
 		as.NewLongValue(currentBlockHeight),
 		as.NewLongValue(blockHeightRetention),
 	)
+	if err != nil {
+		return SetConflictingResult{}, err
+	}
+	return newSetConflictingResult(raw)
 }
 
 // SetLocked locks/unlocks a transaction
-func (w *ClientWrapper) SetLocked(policy *as.WritePolicy, key *as.Key, setValue bool) (map[interface{}]interface{}, error) {
-	return w.execute(policy, key, "setLocked",
+func (w *ClientWrapper) SetLocked(policy *as.WritePolicy, key *as.Key, setValue bool) (SetLockedResult, error) {
+	raw, err := w.execute(policy, key, "setLocked",
 		as.NewValue(setValue),
 	)
+	if err != nil {
+		return SetLockedResult{}, err
+	}
+	return newSetLockedResult(raw)
 }
 
 // PreserveUntil prevents record deletion until specific block height
-func (w *ClientWrapper) PreserveUntil(policy *as.WritePolicy, key *as.Key, blockHeight int64) (map[interface{}]interface{}, error) {
-	return w.execute(policy, key, "preserveUntil",
+func (w *ClientWrapper) PreserveUntil(policy *as.WritePolicy, key *as.Key, blockHeight int64) (PreserveUntilResult, error) {
+	raw, err := w.execute(policy, key, "preserveUntil",
 		as.NewLongValue(blockHeight),
 	)
+	if err != nil {
+		return PreserveUntilResult{}, err
+	}
+	return newPreserveUntilResult(raw)
 }
 
 // IncrementSpentExtraRecs updates spent record counters for pagination
-func (w *ClientWrapper) IncrementSpentExtraRecs(policy *as.WritePolicy, key *as.Key, inc int64, currentBlockHeight int64, blockHeightRetention int64) (map[interface{}]interface{}, error) {
-	return w.execute(policy, key, "incrementSpentExtraRecs",
+func (w *ClientWrapper) IncrementSpentExtraRecs(policy *as.WritePolicy, key *as.Key, inc int64, currentBlockHeight int64, blockHeightRetention int64) (IncrementSpentExtraRecsResult, error) {
+	raw, err := w.execute(policy, key, "incrementSpentExtraRecs",
 		as.NewLongValue(inc),
 		as.NewLongValue(currentBlockHeight),
 		as.NewLongValue(blockHeightRetention),
 	)
+	if err != nil {
+		return IncrementSpentExtraRecsResult{}, err
+	}
+	return newIncrementSpentExtraRecsResult(raw)
 }
 
 // SetDeleteAtHeight manages record expiration (internal logic)
-func (w *ClientWrapper) SetDeleteAtHeight(policy *as.WritePolicy, key *as.Key, currentBlockHeight int64, blockHeightRetention int64) (map[interface{}]interface{}, error) {
-	return w.execute(policy, key, "setDeleteAtHeight",
+func (w *ClientWrapper) SetDeleteAtHeight(policy *as.WritePolicy, key *as.Key, currentBlockHeight int64, blockHeightRetention int64) (SetDeleteAtHeightResult, error) {
+	raw, err := w.execute(policy, key, "setDeleteAtHeight",
 		as.NewLongValue(currentBlockHeight),
 		as.NewLongValue(blockHeightRetention),
 	)
+	if err != nil {
+		return SetDeleteAtHeightResult{}, err
+	}
+	return newSetDeleteAtHeightResult(raw)
 }
@@ -0,0 +1,359 @@
+package teranode
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	as "github.com/aerospike/aerospike-client-go/v8"
+)
+
+// TrimMetrics reports the running totals a Trimmer accumulates across its
+// lifetime, plus a snapshot of its most recently completed pass. All fields
+// are safe to read concurrently while the Trimmer is running.
+type TrimMetrics struct {
+	TrimmedTotal int64
+	// TrimmedPerSec is the trim rate observed during the most recently
+	// completed RunOnce pass.
+	TrimmedPerSec int64
+	// BacklogSize is the number of records still eligible for trimming as
+	// of the end of the most recently completed RunOnce pass.
+	BacklogSize int64
+}
+
+// TrimmerConfig configures a Trimmer's pruning window and pacing.
+type TrimmerConfig struct {
+	// TrimDepth is the rolling window behind the chain tip: on a block at
+	// currentBlockHeight, records eligible for deletion are those whose
+	// deleteAtHeight <= currentBlockHeight - TrimDepth.
+	TrimDepth int64
+	// MaxBatch caps the number of records trimExpired removes per call.
+	MaxBatch int64
+	// Concurrency is the number of partitions scanned in parallel per pass.
+	Concurrency int
+	// RatePerSecond caps the aggregate number of records trimmed per
+	// second across all partitions. Zero means unlimited.
+	RatePerSecond int
+	// DryRun, when true, calls trimExpired in a mode that reports what
+	// would be trimmed without deleting anything.
+	DryRun bool
+}
+
+// DefaultTrimmerConfig returns conservative defaults suitable for a
+// production cluster.
+func DefaultTrimmerConfig() TrimmerConfig {
+	return TrimmerConfig{
+		TrimDepth:   288, // ~2 days of blocks
+		MaxBatch:    1000,
+		Concurrency: 8,
+	}
+}
+
+// Trimmer periodically scans records whose deleteAtHeight (see
+// ClientWrapper.SetDeleteAtHeight) has been reached and removes them. It
+// trims by height only: it has no size setting and does not bound how many
+// records the UTXO set holds. It mirrors the
+// depth-based trimming model: rather than deleting the instant a record
+// expires, it keeps a rolling window of TrimDepth blocks behind the tip so
+// PreserveUntil markers and short reorgs still have room to apply.
+type Trimmer struct {
+	wrapper *ClientWrapper
+	ns      string
+	set     string
+	cfg     TrimmerConfig
+
+	metrics TrimMetrics
+
+	mu       sync.Mutex
+	running  bool
+	cancelFn context.CancelFunc
+	done     chan struct{}
+}
+
+// NewTrimmer creates a Trimmer for the given namespace/set.
+func NewTrimmer(w *ClientWrapper, namespace, set string, cfg TrimmerConfig) *Trimmer {
+	return &Trimmer{
+		wrapper: w,
+		ns:      namespace,
+		set:     set,
+		cfg:     cfg,
+	}
+}
+
+// Metrics returns a snapshot of the Trimmer's running totals.
+func (t *Trimmer) Metrics() TrimMetrics {
+	return TrimMetrics{
+		TrimmedTotal:  atomic.LoadInt64(&t.metrics.TrimmedTotal),
+		TrimmedPerSec: atomic.LoadInt64(&t.metrics.TrimmedPerSec),
+		BacklogSize:   atomic.LoadInt64(&t.metrics.BacklogSize),
+	}
+}
+
+// Start begins calling RunOnce on every new block height pushed to
+// heights, until ctx is cancelled or Stop is called. It returns immediately;
+// the scan loop runs in a background goroutine.
+func (t *Trimmer) Start(ctx context.Context, heights <-chan int64) {
+	t.mu.Lock()
+	if t.running {
+		t.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	t.cancelFn = cancel
+	t.running = true
+	t.done = make(chan struct{})
+	t.mu.Unlock()
+
+	go func() {
+		defer close(t.done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case currentBlockHeight, ok := <-heights:
+				if !ok {
+					return
+				}
+				_, _ = t.RunOnce(ctx, currentBlockHeight)
+			}
+		}
+	}()
+}
+
+// Stop cancels a running Trimmer and waits for its scan loop to exit.
+func (t *Trimmer) Stop() {
+	t.mu.Lock()
+	cancel := t.cancelFn
+	done := t.done
+	t.running = false
+	t.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	if done != nil {
+		<-done
+	}
+}
+
+// RunOnce scans for records eligible for deletion at
+// currentBlockHeight-TrimDepth across t.cfg.Concurrency partitions at once,
+// calling the trimExpired UDF on each and returning the total number of
+// records trimmed (or, in DryRun mode, the number that would be trimmed).
+func (t *Trimmer) RunOnce(ctx context.Context, currentBlockHeight int64) (int64, error) {
+	trimHeight := currentBlockHeight - t.cfg.TrimDepth
+	if trimHeight < 0 {
+		return 0, nil
+	}
+
+	numPartitions := 4096 // Aerospike's fixed partition count
+	concurrency := t.cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	start := time.Now()
+	var total int64
+	var firstErr error
+	var mu sync.Mutex
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	limiter := newRateLimiter(t.cfg.RatePerSecond)
+
+dispatch:
+	for p := 0; p < numPartitions; p++ {
+		select {
+		case <-ctx.Done():
+			mu.Lock()
+			if firstErr == nil {
+				firstErr = ctx.Err()
+			}
+			mu.Unlock()
+			break dispatch
+		case sem <- struct{}{}:
+		}
+
+		partition := p
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			n, err := t.trimPartition(ctx, partition, trimHeight, limiter)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			total += n
+		}()
+	}
+
+	wg.Wait()
+
+	atomic.AddInt64(&t.metrics.TrimmedTotal, total)
+	if elapsed := time.Since(start); elapsed > 0 {
+		atomic.StoreInt64(&t.metrics.TrimmedPerSec, int64(float64(total)/elapsed.Seconds()))
+	}
+	if backlog, err := t.countBacklog(trimHeight); err == nil {
+		atomic.StoreInt64(&t.metrics.BacklogSize, backlog)
+	} else if firstErr == nil {
+		firstErr = err
+	}
+
+	return total, firstErr
+}
+
+// countBacklog runs a single cluster-wide query (not split across
+// partitions) for records still eligible for trimming at trimHeight and
+// returns how many it found. It is only called once per RunOnce pass, not
+// per partition worker, so its cost is bounded regardless of Concurrency.
+func (t *Trimmer) countBacklog(trimHeight int64) (int64, error) {
+	stmt := as.NewStatement(t.ns, t.set)
+	if err := stmt.SetFilter(as.NewRangeFilter("deleteAtHeight", 0, trimHeight)); err != nil {
+		return 0, err
+	}
+
+	recordset, err := t.wrapper.client.Query(nil, stmt)
+	if err != nil {
+		return 0, err
+	}
+	defer recordset.Close()
+
+	var count int64
+	for res := range recordset.Results() {
+		if res.Err != nil {
+			return count, res.Err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// trimPartition repeatedly calls the trimExpired(trimHeight, maxBatch) UDF
+// against partition until it reports fewer than MaxBatch trimmed,
+// indicating the partition is caught up. trimExpired does its own
+// server-side enumeration of the partition's records and honors
+// PreserveUntil internally, so the loop paces itself strictly off the
+// actual-trimmed count the UDF returns rather than anything Go queries
+// separately — a record the UDF skips because it's preserved is never
+// mistaken for one it trimmed, and the loop can't spin on it.
+//
+// In DryRun mode, trimExpired reports what it would trim without deleting
+// anything, so every call would keep re-reporting the same candidates;
+// take a single pass instead of looping to a fixed point that never
+// shrinks.
+func (t *Trimmer) trimPartition(ctx context.Context, partition int, trimHeight int64, limiter *rateLimiter) (int64, error) {
+	key, err := partitionKey(t.ns, t.set, partition)
+	if err != nil {
+		return 0, err
+	}
+
+	return t.trimPartitionWith(ctx, limiter, func() (int64, error) {
+		res, err := t.wrapper.client.Execute(nil, key, "teranode", "trimExpired",
+			as.NewLongValue(trimHeight),
+			as.NewLongValue(t.cfg.MaxBatch),
+			as.NewValue(t.cfg.DryRun),
+		)
+		if err != nil {
+			return 0, err
+		}
+		return toInt64(res), nil
+	})
+}
+
+// trimPartitionWith drives trimPartition's pacing/termination loop against a
+// caller-supplied callTrimExpired, which must behave like a single
+// trimExpired(trimHeight, maxBatch, dryRun) call: it returns the number of
+// records that call actually trimmed (or would trim, in DryRun mode). Pulling
+// this loop out from the Execute call that feeds it lets the termination
+// logic be exercised without a live Aerospike connection.
+func (t *Trimmer) trimPartitionWith(ctx context.Context, limiter *rateLimiter, callTrimExpired func() (int64, error)) (int64, error) {
+	var trimmed int64
+	for {
+		select {
+		case <-ctx.Done():
+			return trimmed, ctx.Err()
+		default:
+		}
+
+		limiter.wait(int(t.cfg.MaxBatch))
+
+		n, err := callTrimExpired()
+		if err != nil {
+			return trimmed, err
+		}
+		trimmed += n
+
+		if t.cfg.DryRun || n < t.cfg.MaxBatch {
+			return trimmed, nil
+		}
+	}
+}
+
+// partitionKey builds a key whose digest is pinned to partition, so a
+// single-record Execute against it reaches the trimExpired UDF running in
+// that partition. Aerospike derives a record's partition from the low 12
+// bits of its 20-byte digest's first two bytes read little-endian; forcing
+// those bits to partition (and zeroing the rest) targets it deterministically
+// without needing a real record to exist there.
+func partitionKey(namespace, set string, partition int) (*as.Key, error) {
+	digest := make([]byte, 20)
+	digest[0] = byte(partition)
+	digest[1] = byte(partition>>8) & 0x0F
+	return as.NewKeyWithDigest(namespace, set, as.NewValue(partition), digest)
+}
+
+// rateLimiter is a minimal token-bucket limiter used to cap the aggregate
+// trim rate across partitions; a nil or zero-rate limiter never blocks.
+type rateLimiter struct {
+	ratePerSecond int
+	mu            sync.Mutex
+	lastRefill    time.Time
+	tokens        int
+}
+
+func newRateLimiter(ratePerSecond int) *rateLimiter {
+	return &rateLimiter{ratePerSecond: ratePerSecond, lastRefill: time.Now()}
+}
+
+func (r *rateLimiter) wait(need int) {
+	if r == nil || r.ratePerSecond <= 0 {
+		return
+	}
+	// The bucket never holds more than ratePerSecond tokens, so a request
+	// for more than that would never be satisfied; wait only for a full
+	// bucket, then take all of need so the balance goes negative and the
+	// caller pays for the rest on its next call instead of spinning forever.
+	threshold := need
+	if threshold > r.ratePerSecond {
+		threshold = r.ratePerSecond
+	}
+	for {
+		r.mu.Lock()
+		// Refill only once at least one whole token has accrued, so a low
+		// rate still makes progress across the short sleeps below.
+		added := int(time.Since(r.lastRefill).Seconds() * float64(r.ratePerSecond))
+		if added > 0 {
+			r.tokens += added
+			r.lastRefill = time.Now()
+			if r.tokens > r.ratePerSecond {
+				r.tokens = r.ratePerSecond
+			}
+		}
+		if r.tokens >= threshold {
+			r.tokens -= need
+			r.mu.Unlock()
+			return
+		}
+		r.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+}
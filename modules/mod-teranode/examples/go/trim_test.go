@@ -0,0 +1,100 @@
+package teranode
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestTrimPartitionWithStopsOnShortCount pins the fix for the infinite-loop
+// bug: once trimExpired reports fewer than MaxBatch trimmed, the partition is
+// caught up and the loop must stop instead of calling trimExpired again.
+func TestTrimPartitionWithStopsOnShortCount(t *testing.T) {
+	tr := &Trimmer{cfg: TrimmerConfig{MaxBatch: 100}}
+
+	calls := 0
+	counts := []int64{100, 100, 40}
+	got, err := tr.trimPartitionWith(context.Background(), nil, func() (int64, error) {
+		n := counts[calls]
+		calls++
+		return n, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != len(counts) {
+		t.Fatalf("expected exactly %d calls once count dropped below MaxBatch, got %d", len(counts), calls)
+	}
+	if want := int64(240); got != want {
+		t.Fatalf("trimmed = %d, want %d", got, want)
+	}
+}
+
+// TestTrimPartitionWithDryRunSinglePass pins that DryRun takes exactly one
+// pass even when the UDF keeps reporting a full MaxBatch, since a dry run
+// never removes anything and so would never shrink on its own.
+func TestTrimPartitionWithDryRunSinglePass(t *testing.T) {
+	tr := &Trimmer{cfg: TrimmerConfig{MaxBatch: 100, DryRun: true}}
+
+	calls := 0
+	got, err := tr.trimPartitionWith(context.Background(), nil, func() (int64, error) {
+		calls++
+		return 100, nil // would always report the same full batch again
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("DryRun should call trimExpired exactly once per partition per pass, got %d calls", calls)
+	}
+	if got != 100 {
+		t.Fatalf("trimmed = %d, want 100", got)
+	}
+}
+
+// TestTrimPartitionWithPropagatesError pins that a failing trimExpired call
+// stops the loop immediately and returns what had been trimmed so far.
+func TestTrimPartitionWithPropagatesError(t *testing.T) {
+	tr := &Trimmer{cfg: TrimmerConfig{MaxBatch: 100}}
+
+	wantErr := errors.New("execute failed")
+	calls := 0
+	got, err := tr.trimPartitionWith(context.Background(), nil, func() (int64, error) {
+		calls++
+		if calls == 2 {
+			return 0, wantErr
+		}
+		return 100, nil
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("err = %v, want %v", err, wantErr)
+	}
+	if calls != 2 {
+		t.Fatalf("expected the loop to stop at the failing call, got %d calls", calls)
+	}
+	if got != 100 {
+		t.Fatalf("trimmed = %d, want 100 (only the first successful call)", got)
+	}
+}
+
+// TestTrimPartitionWithStopsOnCancel pins that a cancelled context stops the
+// loop before the next trimExpired call, rather than running it to
+// completion.
+func TestTrimPartitionWithStopsOnCancel(t *testing.T) {
+	tr := &Trimmer{cfg: TrimmerConfig{MaxBatch: 100}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	_, err := tr.trimPartitionWith(ctx, nil, func() (int64, error) {
+		calls++
+		return 100, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if calls != 0 {
+		t.Fatalf("expected no trimExpired calls once ctx is already cancelled, got %d", calls)
+	}
+}
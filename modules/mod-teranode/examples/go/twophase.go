@@ -0,0 +1,173 @@
+package teranode
+
+import (
+	"context"
+	"fmt"
+
+	as "github.com/aerospike/aerospike-client-go/v8"
+)
+
+// RecordSpend is one leg of a SpendAcrossRecords call: the single UTXO
+// being spent on a particular record's key.
+type RecordSpend struct {
+	Key          *as.Key
+	Offset       int64
+	UtxoHash     []byte
+	SpendingData []byte
+}
+
+// SpendAcrossRecordsResult is the typed result of a SpendAcrossRecords call.
+type SpendAcrossRecordsResult struct {
+	resultBase
+	// Committed is true once phase 2 has converted every leg's tentative
+	// mark into a final spend. It is false whenever SpendAcrossRecords
+	// returns an error: after a prepare-phase failure the legs that had
+	// prepared have been sent abortSpend, while after a commit-phase failure
+	// nothing is rolled back and some legs may already be final.
+	Committed bool
+}
+
+// SpendAcrossRecords atomically spends UTXOs that live in different
+// Aerospike records using a two-phase commit: prepareSpend tentatively
+// marks every leg with coordinatorID and a timeout height, and only once
+// every leg prepares successfully does commitSpend convert the tentative
+// marks into final spends. If any leg fails to prepare, every leg that did
+// succeed is rolled back with abortSpend; a leg whose abort fails keeps its
+// tentative mark until TwoPhaseRecovery rolls it back. coordinatorID should uniquely identify this call (e.g. the
+// spending transaction's ID) so TwoPhaseRecovery can find and roll back
+// orphaned coordinators later.
+func (w *ClientWrapper) SpendAcrossRecords(ctx context.Context, policy *as.WritePolicy, coordinatorID []byte, spends []RecordSpend, currentBlockHeight int64, blockHeightRetention int64, timeoutBlocks int64) (SpendAcrossRecordsResult, error) {
+	if len(spends) == 0 {
+		return SpendAcrossRecordsResult{}, nil
+	}
+
+	timeoutHeight := currentBlockHeight + timeoutBlocks
+	prepared := make([]RecordSpend, 0, len(spends))
+
+	for _, s := range spends {
+		select {
+		case <-ctx.Done():
+			w.abortSpends(policy, coordinatorID, prepared)
+			return SpendAcrossRecordsResult{}, ctx.Err()
+		default:
+		}
+
+		raw, err := w.execute(policy, s.Key, "prepareSpend",
+			as.NewLongValue(s.Offset),
+			as.NewBytesValue(s.UtxoHash),
+			as.NewBytesValue(s.SpendingData),
+			as.NewBytesValue(coordinatorID),
+			as.NewLongValue(timeoutHeight),
+			as.NewLongValue(currentBlockHeight),
+			as.NewLongValue(blockHeightRetention),
+		)
+		if err != nil {
+			w.abortSpends(policy, coordinatorID, prepared)
+			return SpendAcrossRecordsResult{}, err
+		}
+		base, uerr := unmarshal(raw)
+		if uerr != nil {
+			w.abortSpends(policy, coordinatorID, prepared)
+			return SpendAcrossRecordsResult{resultBase: base}, uerr
+		}
+		prepared = append(prepared, s)
+	}
+
+	if err := w.commitSpends(policy, coordinatorID, prepared); err != nil {
+		// Once any leg has reached commitSpend, the transaction can no
+		// longer be aborted: a leg may already be final. The caller must
+		// retry SpendAcrossRecords with the same coordinatorID until it
+		// succeeds rather than treating this as a rolled-back attempt;
+		// commitSpend is idempotent on an already-committed leg.
+		return SpendAcrossRecordsResult{}, fmt.Errorf("teranode: commit phase incomplete, retry with the same coordinatorID: %w", err)
+	}
+
+	return SpendAcrossRecordsResult{Committed: true}, nil
+}
+
+// commitSpends converts every leg's tentative mark into a final spend. It
+// attempts every leg even after one fails, since a partially committed
+// transaction must be retried to completion rather than abandoned.
+func (w *ClientWrapper) commitSpends(policy *as.WritePolicy, coordinatorID []byte, spends []RecordSpend) error {
+	var firstErr error
+	for _, s := range spends {
+		if _, err := w.execute(policy, s.Key, "commitSpend",
+			as.NewLongValue(s.Offset),
+			as.NewBytesValue(coordinatorID),
+		); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// abortSpends clears the tentative mark left by prepareSpend on every leg,
+// on a best-effort basis; a leg that fails to abort is left for
+// TwoPhaseRecovery to find once its timeout height passes.
+func (w *ClientWrapper) abortSpends(policy *as.WritePolicy, coordinatorID []byte, spends []RecordSpend) {
+	for _, s := range spends {
+		_, _ = w.execute(policy, s.Key, "abortSpend",
+			as.NewLongValue(s.Offset),
+			as.NewBytesValue(coordinatorID),
+		)
+	}
+}
+
+// TwoPhaseRecovery finds records whose tentative spend marks (left by
+// prepareSpend) have exceeded their timeout height and rolls them back, so
+// a coordinator that crashes between phase 1 and phase 2 never wedges a
+// UTXO in the tentative state forever.
+type TwoPhaseRecovery struct {
+	wrapper *ClientWrapper
+	ns      string
+	set     string
+}
+
+// NewTwoPhaseRecovery creates a TwoPhaseRecovery for the given
+// namespace/set.
+func NewTwoPhaseRecovery(w *ClientWrapper, namespace, set string) *TwoPhaseRecovery {
+	return &TwoPhaseRecovery{wrapper: w, ns: namespace, set: set}
+}
+
+// ScanAndRollback queries for every record whose tentativeTimeoutHeight is
+// at or below currentBlockHeight and calls abortSpend on it, returning the
+// number of records rolled back.
+func (r *TwoPhaseRecovery) ScanAndRollback(currentBlockHeight int64) (int, error) {
+	stmt := as.NewStatement(r.ns, r.set)
+	if err := stmt.SetFilter(as.NewRangeFilter("tentativeTimeoutHeight", 0, currentBlockHeight)); err != nil {
+		return 0, err
+	}
+
+	recordset, err := r.wrapper.client.Query(nil, stmt)
+	if err != nil {
+		return 0, err
+	}
+	defer recordset.Close()
+
+	var rolledBack int
+	for res := range recordset.Results() {
+		if res.Err != nil {
+			return rolledBack, res.Err
+		}
+
+		coordinatorID, ok := res.Record.Bins["tentativeCoordinatorID"].([]byte)
+		if !ok {
+			return rolledBack, fmt.Errorf("teranode: rollback: missing tentativeCoordinatorID for key %v", res.Record.Key)
+		}
+		offsetBin, ok := res.Record.Bins["tentativeOffset"]
+		if !ok {
+			return rolledBack, fmt.Errorf("teranode: rollback: missing tentativeOffset for key %v", res.Record.Key)
+		}
+		offset := toInt64(offsetBin)
+
+		if _, err := r.wrapper.execute(nil, res.Record.Key, "abortSpend",
+			as.NewLongValue(offset),
+			as.NewBytesValue(coordinatorID),
+		); err != nil {
+			return rolledBack, fmt.Errorf("teranode: rollback failed for key %v: %w", res.Record.Key, err)
+		}
+		rolledBack++
+	}
+
+	return rolledBack, nil
+}